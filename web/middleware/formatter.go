@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// LogEntry describes a single completed request, independent of how it will
+// be rendered. It carries the fields needed by every formatter below; not
+// every formatter uses every field.
+type LogEntry struct {
+	ReqID      string
+	Start      time.Time
+	End        time.Time
+	Method     string
+	URL        string
+	Proto      string
+	Status     int
+	Bytes      int64
+	RemoteAddr string
+	Referrer   string
+	UserAgent  string
+}
+
+// Duration is the time taken to serve the request described by this entry.
+func (e LogEntry) Duration() time.Duration {
+	return e.End.Sub(e.Start)
+}
+
+// LogFormatter renders a completed LogEntry into a single log line's worth
+// of bytes, without a trailing newline. Implementations let Logger's output
+// be dropped straight into existing log pipelines instead of requiring a
+// replacement middleware.
+type LogFormatter interface {
+	Format(entry LogEntry) []byte
+}
+
+func dashIfEmpty(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// CLFFormatter renders entries in the Common Log Format, as produced by
+// Apache and nginx.
+type CLFFormatter struct{}
+
+func (CLFFormatter) Format(e LogEntry) []byte {
+	return []byte(fmt.Sprintf("%s - - [%s] %q %d %d",
+		dashIfEmpty(e.RemoteAddr),
+		e.End.Format("02/Jan/2006:15:04:05 -0700"),
+		fmt.Sprintf("%s %s %s", e.Method, e.URL, e.Proto),
+		e.Status,
+		e.Bytes,
+	))
+}
+
+// CombinedFormatter renders entries in the Combined Log Format: the Common
+// Log Format plus the referrer and user-agent fields.
+type CombinedFormatter struct{}
+
+func (CombinedFormatter) Format(e LogEntry) []byte {
+	return []byte(fmt.Sprintf("%s %q %q",
+		CLFFormatter{}.Format(e),
+		dashIfEmpty(e.Referrer),
+		dashIfEmpty(e.UserAgent),
+	))
+}
+
+// JSONFormatter renders entries as a single line of JSON, suitable for
+// shipping to Elasticsearch, Datadog, or any other structured log sink.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(e LogEntry) []byte {
+	line := struct {
+		ReqID      string    `json:"req_id,omitempty"`
+		Time       time.Time `json:"time"`
+		Method     string    `json:"method"`
+		URL        string    `json:"url"`
+		Proto      string    `json:"proto"`
+		Status     int       `json:"status"`
+		Bytes      int64     `json:"bytes"`
+		DurationMS float64   `json:"duration_ms"`
+		RemoteAddr string    `json:"remote_addr"`
+		Referrer   string    `json:"referrer,omitempty"`
+		UserAgent  string    `json:"user_agent,omitempty"`
+	}{
+		ReqID:      e.ReqID,
+		Time:       e.End,
+		Method:     e.Method,
+		URL:        e.URL,
+		Proto:      e.Proto,
+		Status:     e.Status,
+		Bytes:      e.Bytes,
+		DurationMS: float64(e.Duration()) / float64(time.Millisecond),
+		RemoteAddr: e.RemoteAddr,
+		Referrer:   e.Referrer,
+		UserAgent:  e.UserAgent,
+	}
+
+	b, err := json.Marshal(line)
+	if err != nil {
+		return []byte(fmt.Sprintf(`{"error":%q}`, err.Error()))
+	}
+	return b
+}
+
+// PrettyFormatter renders entries as a single colored line with the request
+// ID, method, URL, status and latency. It's the default formatter, exposed
+// as a named LogFormatter so it composes with the rest of this package's
+// pluggable formatting.
+type PrettyFormatter struct{}
+
+func (PrettyFormatter) Format(e LogEntry) []byte {
+	var buf bytes.Buffer
+
+	if e.ReqID != "" {
+		cW(&buf, bBlack, "[%s] ", e.ReqID)
+	}
+	cW(&buf, bMagenta, "%s ", e.Method)
+	cW(&buf, nBlue, "%q ", e.URL)
+	buf.WriteString("-> ")
+
+	status := e.Status
+	switch {
+	case status < 200:
+		cW(&buf, bBlue, "%03d", status)
+	case status < 300:
+		cW(&buf, bGreen, "%03d", status)
+	case status < 400:
+		cW(&buf, bCyan, "%03d", status)
+	case status < 500:
+		cW(&buf, bYellow, "%03d", status)
+	default:
+		cW(&buf, bRed, "%03d", status)
+	}
+
+	dt := e.Duration()
+	buf.WriteString(" in ")
+	switch {
+	case dt < 500*time.Millisecond:
+		cW(&buf, nGreen, "%s", dt)
+	case dt < 5*time.Second:
+		cW(&buf, nYellow, "%s", dt)
+	default:
+		cW(&buf, nRed, "%s", dt)
+	}
+
+	return buf.Bytes()
+}
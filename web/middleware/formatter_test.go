@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var testEntry = LogEntry{
+	ReqID:      "host/abc-000001",
+	Start:      time.Date(2026, time.January, 2, 15, 4, 5, 0, time.FixedZone("", 0)),
+	End:        time.Date(2026, time.January, 2, 15, 4, 5, 250000000, time.FixedZone("", 0)),
+	Method:     "GET",
+	URL:        "/items/42",
+	Proto:      "HTTP/1.1",
+	Status:     200,
+	Bytes:      1024,
+	RemoteAddr: "10.0.0.1:1234",
+	Referrer:   "http://example.com/",
+	UserAgent:  "test-agent/1.0",
+}
+
+func TestCLFFormatter(t *testing.T) {
+	got := string(CLFFormatter{}.Format(testEntry))
+	want := `10.0.0.1:1234 - - [02/Jan/2026:15:04:05 +0000] "GET /items/42 HTTP/1.1" 200 1024`
+	if got != want {
+		t.Errorf("CLFFormatter.Format() = %q, want %q", got, want)
+	}
+}
+
+func TestCombinedFormatter(t *testing.T) {
+	got := string(CombinedFormatter{}.Format(testEntry))
+	want := `10.0.0.1:1234 - - [02/Jan/2026:15:04:05 +0000] "GET /items/42 HTTP/1.1" 200 1024 "http://example.com/" "test-agent/1.0"`
+	if got != want {
+		t.Errorf("CombinedFormatter.Format() = %q, want %q", got, want)
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	got := JSONFormatter{}.Format(testEntry)
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("JSONFormatter.Format() produced invalid JSON: %v", err)
+	}
+
+	if decoded["req_id"] != testEntry.ReqID {
+		t.Errorf("req_id = %v, want %v", decoded["req_id"], testEntry.ReqID)
+	}
+	if decoded["method"] != testEntry.Method {
+		t.Errorf("method = %v, want %v", decoded["method"], testEntry.Method)
+	}
+	if decoded["status"] != float64(testEntry.Status) {
+		t.Errorf("status = %v, want %v", decoded["status"], testEntry.Status)
+	}
+	if decoded["duration_ms"] != 250.0 {
+		t.Errorf("duration_ms = %v, want 250", decoded["duration_ms"])
+	}
+}
+
+func TestPrettyFormatter(t *testing.T) {
+	got := string(PrettyFormatter{}.Format(testEntry))
+	want := `[host/abc-000001] GET "/items/42" -> 200 in 250ms`
+	if got != want {
+		t.Errorf("PrettyFormatter.Format() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/zenazn/goji/web"
+	"github.com/zenazn/goji/web/mutil"
+)
+
+// redactedValue replaces each part of a header's value matched by that
+// header's Redact pattern; with the default patterns, that's the whole value.
+const redactedValue = "REDACTED"
+
+// defaultRedact lists the headers whose values are redacted by default in
+// verbose mode, since they routinely carry secrets that shouldn't end up in
+// log aggregators.
+func defaultRedact() map[string]*regexp.Regexp {
+	all := regexp.MustCompile(`.*`)
+	return map[string]*regexp.Regexp{
+		"Authorization": all,
+		"Cookie":        all,
+		"Set-Cookie":    all,
+	}
+}
+
+// LoggerConfig builds a logging middleware with more control than the
+// defaults used by Logger and LoggerVerbose: which headers to print (and
+// redact) in verbose mode, a sampling rate for high-QPS services, and a slow
+// request threshold that bypasses sampling. Build one with NewLoggerConfig
+// and install the result of its Middleware method.
+type LoggerConfig struct {
+	// Verbose additionally logs request headers, subject to IncludeHeaders,
+	// ExcludeHeaders and Redact below. Header logging follows the same
+	// SampleRate/SlowThreshold decision as the summary line: a sampled-out
+	// request logs neither.
+	Verbose bool
+
+	// IncludeHeaders, if non-empty, restricts verbose header logging to
+	// exactly these header names (case-insensitive). An empty slice means
+	// all headers are eligible, subject to ExcludeHeaders.
+	IncludeHeaders []string
+	// ExcludeHeaders lists header names to omit entirely from verbose
+	// logging (case-insensitive).
+	ExcludeHeaders []string
+	// Redact maps a header name (case-insensitive) to a regexp; every
+	// match of that regexp in the header's value is replaced with
+	// "REDACTED" before it's logged. Defaults to redacting Authorization,
+	// Cookie and Set-Cookie in their entirety.
+	Redact map[string]*regexp.Regexp
+
+	// SampleRate is the fraction, in [0, 1], of successful (2xx) requests
+	// that are logged. 1xx, 3xx, 4xx and 5xx responses are always logged
+	// regardless of SampleRate. The zero value logs everything.
+	SampleRate float64
+	// SlowThreshold, if non-zero, promotes any request slower than this
+	// duration to its own log line even when SampleRate would otherwise
+	// have dropped it.
+	SlowThreshold time.Duration
+
+	// Now is used to obtain the current time, and the time elapsed during
+	// a request. It defaults to time.Now; tests can supply a deterministic
+	// clock instead.
+	Now func() time.Time
+
+	// Formatter renders each logged request into a line of output. It
+	// defaults to PrettyFormatter, a colored console line; set it to CLFFormatter, CombinedFormatter or
+	// JSONFormatter to ship access logs straight into an existing log
+	// pipeline instead.
+	Formatter LogFormatter
+}
+
+// NewLoggerConfig returns a LoggerConfig with the same defaults used by
+// Logger: not verbose, no sampling, no slow-request promotion, redacting the
+// usual sensitive headers, and rendering with PrettyFormatter.
+func NewLoggerConfig() *LoggerConfig {
+	return &LoggerConfig{
+		Redact:     defaultRedact(),
+		SampleRate: 1,
+		Now:        time.Now,
+		Formatter:  PrettyFormatter{},
+	}
+}
+
+// Middleware builds a web.MiddlewareType from this configuration.
+func (lc *LoggerConfig) Middleware() func(*web.C, http.Handler) http.Handler {
+	return func(c *web.C, h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqID := GetReqID(*c)
+
+			printStart(reqID, r)
+
+			lw := mutil.WrapWriter(w)
+			now := lc.now()
+
+			t1 := now()
+			h.ServeHTTP(lw, r)
+
+			if lw.Status() == 0 {
+				lw.WriteHeader(http.StatusOK)
+			}
+			t2 := now()
+			dt := t2.Sub(t1)
+
+			if lc.shouldLog(lw.Status(), dt) {
+				if lc.Verbose {
+					lc.printHeaders(reqID, r)
+				}
+
+				entry := LogEntry{
+					ReqID:      reqID,
+					Start:      t1,
+					End:        t2,
+					Method:     r.Method,
+					URL:        r.URL.String(),
+					Proto:      r.Proto,
+					Status:     lw.Status(),
+					Bytes:      int64(lw.BytesWritten()),
+					RemoteAddr: r.RemoteAddr,
+					Referrer:   r.Referer(),
+					UserAgent:  r.UserAgent(),
+				}
+				log.Print(string(lc.formatter().Format(entry)))
+			}
+		})
+	}
+}
+
+// now returns lc.Now, defaulting to time.Now so a zero-value LoggerConfig
+// (built as a struct literal rather than via NewLoggerConfig) doesn't panic.
+func (lc *LoggerConfig) now() func() time.Time {
+	if lc.Now == nil {
+		return time.Now
+	}
+	return lc.Now
+}
+
+// formatter returns lc.Formatter, defaulting to PrettyFormatter so a
+// zero-value LoggerConfig doesn't panic on a nil interface call.
+func (lc *LoggerConfig) formatter() LogFormatter {
+	if lc.Formatter == nil {
+		return PrettyFormatter{}
+	}
+	return lc.Formatter
+}
+
+func (lc *LoggerConfig) shouldLog(status int, dt time.Duration) bool {
+	if status < 200 || status >= 300 {
+		return true
+	}
+	if lc.SlowThreshold > 0 && dt >= lc.SlowThreshold {
+		return true
+	}
+	rate := lc.SampleRate
+	if rate <= 0 {
+		rate = 1
+	}
+	if rate >= 1 {
+		return true
+	}
+	return rand.Float64() < rate
+}
+
+func (lc *LoggerConfig) includeHeader(name string) bool {
+	if len(lc.IncludeHeaders) > 0 {
+		found := false
+		for _, h := range lc.IncludeHeaders {
+			if strings.EqualFold(h, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, h := range lc.ExcludeHeaders {
+		if strings.EqualFold(h, name) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (lc *LoggerConfig) redact(name, value string) string {
+	for header, pattern := range lc.Redact {
+		if strings.EqualFold(header, name) {
+			return pattern.ReplaceAllString(value, redactedValue)
+		}
+	}
+	return value
+}
+
+// printStart logs the "Started ..." line Logger has always emitted at
+// request entry, independent of Formatter and shouldLog: it's meant to
+// surface in-flight/hung requests, so it isn't subject to sampling or tied
+// to the access-log format used for the completion line.
+func printStart(reqID string, r *http.Request) {
+	var buf bytes.Buffer
+
+	if reqID != "" {
+		cW(&buf, bBlack, "[%s] ", reqID)
+	}
+	buf.WriteString("Started ")
+	cW(&buf, bMagenta, "%s ", r.Method)
+	cW(&buf, nBlue, "%q ", r.URL.String())
+	buf.WriteString("from ")
+
+	if h := r.Header.Get("X-Forwarded-For"); h != "" {
+		buf.WriteString(h)
+	} else {
+		buf.WriteString(r.RemoteAddr)
+	}
+
+	log.Print(buf.String())
+}
+
+func (lc *LoggerConfig) printHeaders(reqID string, r *http.Request) {
+	var buf bytes.Buffer
+
+	for k, v := range r.Header {
+		if !lc.includeHeader(k) {
+			continue
+		}
+
+		if reqID != "" {
+			cW(&buf, bBlack, "[%s] ", reqID)
+		}
+
+		buf.WriteString(fmt.Sprintf("%s: ", k))
+
+		for ks, vs := range v {
+			buf.WriteString(lc.redact(k, vs))
+
+			if ks+1 < len(v) {
+				buf.WriteString(", ")
+			}
+		}
+
+		log.Print(buf.String())
+
+		buf.Reset()
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zenazn/goji/web"
+)
+
+func TestLoggerConfigShouldLog(t *testing.T) {
+	cases := []struct {
+		name          string
+		status        int
+		dt            time.Duration
+		sampleRate    float64
+		slowThreshold time.Duration
+		want          bool
+	}{
+		{"2xx always logged at full sample rate", 200, time.Millisecond, 1, 0, true},
+		{"2xx dropped at near-zero sample rate", 200, time.Millisecond, 0.0000001, 0, false},
+		{"4xx always logged regardless of sample rate", 404, time.Millisecond, 0, 0, true},
+		{"5xx always logged regardless of sample rate", 500, time.Millisecond, 0, 0, true},
+		{"slow request promoted past sampling", 200, time.Second, 0.0000001, 100 * time.Millisecond, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lc := NewLoggerConfig()
+			lc.SampleRate = tc.sampleRate
+			lc.SlowThreshold = tc.slowThreshold
+
+			if got := lc.shouldLog(tc.status, tc.dt); got != tc.want {
+				t.Errorf("shouldLog(%d, %s) = %v, want %v", tc.status, tc.dt, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoggerConfigDeterministicClock(t *testing.T) {
+	start := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+
+	lc := NewLoggerConfig()
+	lc.Now = func() time.Time { return clock }
+
+	var entry LogEntry
+	lc.Formatter = formatterFunc(func(e LogEntry) []byte {
+		entry = e
+		return nil
+	})
+
+	var c web.C
+	h := lc.Middleware()(&c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clock = clock.Add(42 * time.Millisecond)
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if got := entry.Duration(); got != 42*time.Millisecond {
+		t.Errorf("entry duration = %s, want 42ms", got)
+	}
+}
+
+func TestLoggerConfigZeroValueDoesNotPanic(t *testing.T) {
+	lc := &LoggerConfig{}
+
+	var c web.C
+	h := lc.Middleware()(&c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatalf("zero-value LoggerConfig panicked: %v", err)
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+type formatterFunc func(LogEntry) []byte
+
+func (f formatterFunc) Format(e LogEntry) []byte { return f(e) }
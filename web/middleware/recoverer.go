@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/zenazn/goji/web"
+	"github.com/zenazn/goji/web/mutil"
+)
+
+// PanicHandler is called by Recoverer when the wrapped handler panics. It
+// receives the panic value and the stack trace captured at the point of the
+// panic, and is responsible for writing an appropriate response body if one
+// hasn't been sent yet. The default, set via SetPanicHandler, writes an empty
+// 500 response.
+type PanicHandler func(c *web.C, w http.ResponseWriter, r *http.Request, err interface{}, stack []byte)
+
+var defaultPanicHandlerVal atomic.Value // PanicHandler
+
+func init() {
+	defaultPanicHandlerVal.Store(PanicHandler(defaultPanicHandler))
+}
+
+// SetPanicHandler replaces the PanicHandler used by the package-level
+// Recoverer middleware. Applications that need a distinct handler per mux,
+// or that want to avoid a process-wide singleton altogether, should use
+// NewRecoverer instead.
+func SetPanicHandler(h PanicHandler) {
+	if h == nil {
+		h = defaultPanicHandler
+	}
+	defaultPanicHandlerVal.Store(h)
+}
+
+func defaultPanicHandler(c *web.C, w http.ResponseWriter, r *http.Request, err interface{}, stack []byte) {
+	w.WriteHeader(http.StatusInternalServerError)
+}
+
+// Recoverer is a middleware that recovers from panics raised while serving a
+// request, logs a colored, request-ID-tagged stack trace, and writes an HTTP
+// 500 if no response has been sent yet. It should generally be one of the
+// first middleware in the stack, so that it can catch panics raised by
+// middleware further down the chain as well as by the application itself.
+//
+// Recoverer is a thin wrapper around NewRecoverer using the PanicHandler set
+// by SetPanicHandler (or the default, if none has been set). Applications
+// that want a handler scoped to a single mux, rather than shared
+// process-wide, should call NewRecoverer directly instead.
+func Recoverer(c *web.C, h http.Handler) http.Handler {
+	handler := defaultPanicHandlerVal.Load().(PanicHandler)
+	return NewRecoverer(handler)(c, h)
+}
+
+// NewRecoverer returns a Recoverer middleware that calls handler on panic,
+// instead of whatever was last passed to SetPanicHandler. Use this when an
+// application needs a panic handler scoped to a single mux rather than
+// shared process-wide, or wants to avoid mutable global state entirely.
+func NewRecoverer(handler PanicHandler) func(*web.C, http.Handler) http.Handler {
+	if handler == nil {
+		handler = defaultPanicHandler
+	}
+	return func(c *web.C, h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lw := mutil.WrapWriter(w)
+
+			defer func() {
+				if err := recover(); err != nil {
+					reqID := GetReqID(*c)
+					stack := debug.Stack()
+
+					printPanic(reqID, err, stack)
+
+					if lw.Status() == 0 {
+						handler(c, lw, r, err, stack)
+					}
+				}
+			}()
+
+			h.ServeHTTP(lw, r)
+		})
+	}
+}
+
+func printPanic(reqID string, err interface{}, stack []byte) {
+	var buf bytes.Buffer
+
+	if reqID != "" {
+		cW(&buf, bBlack, "[%s] ", reqID)
+	}
+	cW(&buf, bRed, "PANIC: %v", err)
+
+	log.Printf("%s\n%s", buf.String(), stack)
+}
@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zenazn/goji/web"
+)
+
+func TestRecovererWrites500WhenNoResponseSent(t *testing.T) {
+	m := web.New()
+	m.Use(Recoverer)
+	m.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	m.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestRecovererLeavesResponseAloneIfAlreadySent(t *testing.T) {
+	m := web.New()
+	m.Use(Recoverer)
+	m.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	m.ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d (handler's own status should survive)", rr.Code, http.StatusTeapot)
+	}
+}
+
+func TestRecovererHonorsSetPanicHandler(t *testing.T) {
+	called := false
+	SetPanicHandler(func(c *web.C, w http.ResponseWriter, r *http.Request, err interface{}, stack []byte) {
+		called = true
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	defer SetPanicHandler(nil)
+
+	m := web.New()
+	m.Use(Recoverer)
+	m.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	m.ServeHTTP(rr, r)
+
+	if !called {
+		t.Error("custom PanicHandler set via SetPanicHandler was not called")
+	}
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusBadGateway)
+	}
+}
+
+func TestNewRecovererUsesItsOwnHandler(t *testing.T) {
+	var gotErr interface{}
+	recoverer := NewRecoverer(func(c *web.C, w http.ResponseWriter, r *http.Request, err interface{}, stack []byte) {
+		gotErr = err
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	m := web.New()
+	m.Use(recoverer)
+	m.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("custom boom")
+	})
+
+	rr := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	m.ServeHTTP(rr, r)
+
+	if gotErr != "custom boom" {
+		t.Errorf("handler saw err = %v, want %q", gotErr, "custom boom")
+	}
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zenazn/goji/web"
+	"github.com/zenazn/goji/web/mutil"
+)
+
+// Stats accumulates counters and latency samples for every request that
+// passes through its Handler middleware. It is modeled on the thoas/stats
+// pattern, but built directly into goji so applications don't need to pull
+// in a separate library to expose a /stats endpoint.
+//
+// A Stats value must be created with NewStats and is safe for concurrent
+// use.
+type Stats struct {
+	start time.Time
+
+	requests int64
+	inFlight int64
+	bytesIn  int64
+	bytesOut int64
+	statuses [6]int64 // index by status/100, 1xx..5xx in 1..5, unknown in 0
+
+	mu        sync.Mutex
+	byRoute   map[string]int64
+	latencies []time.Duration // ring of recent latencies, used for percentiles
+}
+
+const statsMaxLatencySamples = 1024
+
+// NewStats returns a new, empty Stats collector.
+func NewStats() *Stats {
+	return &Stats{
+		start:   time.Now(),
+		byRoute: map[string]int64{},
+	}
+}
+
+// Handler wraps h, recording counters and latency for every request it
+// serves. It has the signature web.MiddlewareType expects, so it can be
+// installed with m.Use(s.Handler).
+func (s *Stats) Handler(c *web.C, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&s.requests, 1)
+		atomic.AddInt64(&s.inFlight, 1)
+		defer atomic.AddInt64(&s.inFlight, -1)
+
+		// r.ContentLength is -1 for chunked/unknown-length request bodies;
+		// leave bytesIn alone rather than decrementing it, same as most
+		// stats libraries do.
+		if r.ContentLength > 0 {
+			atomic.AddInt64(&s.bytesIn, r.ContentLength)
+		}
+
+		lw := mutil.WrapWriter(w)
+		t1 := time.Now()
+
+		h.ServeHTTP(lw, r)
+
+		if lw.Status() == 0 {
+			lw.WriteHeader(http.StatusOK)
+		}
+		dt := time.Since(t1)
+
+		s.record(c, lw.Status(), int64(lw.BytesWritten()), dt)
+	})
+}
+
+func (s *Stats) record(c *web.C, status int, bytesOut int64, dt time.Duration) {
+	atomic.AddInt64(&s.bytesOut, bytesOut)
+
+	class := status / 100
+	if class < 1 || class > 5 {
+		class = 0
+	}
+	atomic.AddInt64(&s.statuses[class], 1)
+
+	route := "<unmatched>"
+	if c != nil {
+		if p := web.GetMatch(*c).Pattern; p != nil {
+			route = fmt.Sprintf("%s", p)
+		}
+	}
+
+	s.mu.Lock()
+	s.byRoute[route]++
+	s.latencies = append(s.latencies, dt)
+	if len(s.latencies) > statsMaxLatencySamples {
+		s.latencies = s.latencies[len(s.latencies)-statsMaxLatencySamples:]
+	}
+	s.mu.Unlock()
+}
+
+func (s *Stats) percentiles() (p50, p90, p99 time.Duration) {
+	s.mu.Lock()
+	samples := append([]time.Duration(nil), s.latencies...)
+	s.mu.Unlock()
+
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+	pick := func(pct float64) time.Duration {
+		idx := int(pct * float64(len(samples)))
+		if idx >= len(samples) {
+			idx = len(samples) - 1
+		}
+		return samples[idx]
+	}
+
+	return pick(0.50), pick(0.90), pick(0.99)
+}
+
+// Data returns a point-in-time snapshot of the collected statistics, suitable
+// for encoding as JSON.
+func (s *Stats) Data() map[string]interface{} {
+	p50, p90, p99 := s.percentiles()
+
+	s.mu.Lock()
+	byRoute := make(map[string]int64, len(s.byRoute))
+	for k, v := range s.byRoute {
+		byRoute[k] = v
+	}
+	s.mu.Unlock()
+
+	return map[string]interface{}{
+		"uptime_seconds":     time.Since(s.start).Seconds(),
+		"requests_total":     atomic.LoadInt64(&s.requests),
+		"requests_in_flight": atomic.LoadInt64(&s.inFlight),
+		"bytes_in":           atomic.LoadInt64(&s.bytesIn),
+		"bytes_out":          atomic.LoadInt64(&s.bytesOut),
+		"status_classes": map[string]int64{
+			"1xx":     atomic.LoadInt64(&s.statuses[1]),
+			"2xx":     atomic.LoadInt64(&s.statuses[2]),
+			"3xx":     atomic.LoadInt64(&s.statuses[3]),
+			"4xx":     atomic.LoadInt64(&s.statuses[4]),
+			"5xx":     atomic.LoadInt64(&s.statuses[5]),
+			"unknown": atomic.LoadInt64(&s.statuses[0]),
+		},
+		"by_route": byRoute,
+		"latency": map[string]interface{}{
+			"p50": p50.String(),
+			"p90": p90.String(),
+			"p99": p99.String(),
+		},
+	}
+}
+
+// ServeHTTP renders the current snapshot as JSON, so a Stats value can be
+// mounted directly as an endpoint, e.g. m.Get("/stats", stats).
+func (s *Stats) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Data())
+}
+
+// Prometheus renders the current snapshot in the Prometheus text exposition
+// format, so Stats can be scraped directly without an intermediate
+// translation layer.
+func (s *Stats) Prometheus(w http.ResponseWriter, r *http.Request) {
+	p50, p90, p99 := s.percentiles()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+	fmt.Fprintf(w, "# HELP goji_requests_total Total number of requests served.\n")
+	fmt.Fprintf(w, "# TYPE goji_requests_total counter\n")
+	fmt.Fprintf(w, "goji_requests_total %d\n", atomic.LoadInt64(&s.requests))
+
+	fmt.Fprintf(w, "# HELP goji_requests_in_flight Number of requests currently being served.\n")
+	fmt.Fprintf(w, "# TYPE goji_requests_in_flight gauge\n")
+	fmt.Fprintf(w, "goji_requests_in_flight %d\n", atomic.LoadInt64(&s.inFlight))
+
+	fmt.Fprintf(w, "# HELP goji_bytes_in_total Total number of request bytes read.\n")
+	fmt.Fprintf(w, "# TYPE goji_bytes_in_total counter\n")
+	fmt.Fprintf(w, "goji_bytes_in_total %d\n", atomic.LoadInt64(&s.bytesIn))
+
+	fmt.Fprintf(w, "# HELP goji_bytes_out_total Total number of response bytes written.\n")
+	fmt.Fprintf(w, "# TYPE goji_bytes_out_total counter\n")
+	fmt.Fprintf(w, "goji_bytes_out_total %d\n", atomic.LoadInt64(&s.bytesOut))
+
+	fmt.Fprintf(w, "# HELP goji_requests_status_total Total number of requests by status class.\n")
+	fmt.Fprintf(w, "# TYPE goji_requests_status_total counter\n")
+	for class, label := range map[int]string{1: "1xx", 2: "2xx", 3: "3xx", 4: "4xx", 5: "5xx"} {
+		fmt.Fprintf(w, "goji_requests_status_total{class=%q} %d\n", label, atomic.LoadInt64(&s.statuses[class]))
+	}
+
+	fmt.Fprintf(w, "# HELP goji_request_duration_seconds Request latency percentiles.\n")
+	fmt.Fprintf(w, "# TYPE goji_request_duration_seconds summary\n")
+	for q, d := range map[string]time.Duration{"0.5": p50, "0.9": p90, "0.99": p99} {
+		fmt.Fprintf(w, "goji_request_duration_seconds{quantile=%q} %s\n", q, strconv.FormatFloat(d.Seconds(), 'f', -1, 64))
+	}
+
+	fmt.Fprintf(w, "goji_uptime_seconds %s\n", strconv.FormatFloat(time.Since(s.start).Seconds(), 'f', -1, 64))
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/zenazn/goji/web"
+)
+
+func TestStatsData(t *testing.T) {
+	s := NewStats()
+
+	m := web.New()
+	m.Get("/items/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	})
+	// Router stashes the routing Match in c.Env so that Stats, running as a
+	// post-routing middleware, can attribute the request to its pattern.
+	m.Use(m.Router)
+	m.Use(s.Handler)
+
+	r, _ := http.NewRequest("GET", "/items/42", nil)
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	r, _ = http.NewRequest("GET", "/does-not-exist", nil)
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	data := s.Data()
+
+	if data["requests_total"].(int64) != 2 {
+		t.Errorf("requests_total = %v, want 2", data["requests_total"])
+	}
+
+	byRoute := data["by_route"].(map[string]int64)
+	if len(byRoute) != 2 {
+		t.Fatalf("by_route = %v, want 2 distinct buckets", byRoute)
+	}
+	if byRoute["<unmatched>"] != 1 {
+		t.Errorf("by_route[<unmatched>] = %d, want 1", byRoute["<unmatched>"])
+	}
+
+	foundMatched := false
+	for route, count := range byRoute {
+		if route != "<unmatched>" {
+			foundMatched = true
+			if count != 1 {
+				t.Errorf("by_route[%q] = %d, want 1", route, count)
+			}
+			if !strings.Contains(route, "/items/:id") {
+				t.Errorf("by_route key %q does not reference the matched pattern", route)
+			}
+		}
+	}
+	if !foundMatched {
+		t.Error("matched request was not bucketed under its route pattern")
+	}
+
+	statusClasses := data["status_classes"].(map[string]int64)
+	if statusClasses["2xx"] != 1 {
+		t.Errorf("status_classes[2xx] = %d, want 1", statusClasses["2xx"])
+	}
+	if statusClasses["4xx"] != 1 {
+		t.Errorf("status_classes[4xx] = %d, want 1", statusClasses["4xx"])
+	}
+}
+
+func TestStatsPrometheus(t *testing.T) {
+	s := NewStats()
+
+	m := web.New()
+	m.Get("/ping", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	m.Use(m.Router)
+	m.Use(s.Handler)
+
+	r, _ := http.NewRequest("GET", "/ping", nil)
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	w := httptest.NewRecorder()
+	s.Prometheus(w, r)
+
+	body := w.Body.String()
+	for _, want := range []string{
+		"goji_requests_total 1",
+		"goji_requests_status_total{class=\"2xx\"} 1",
+		"goji_request_duration_seconds{quantile=\"0.5\"}",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Prometheus output missing %q; got:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/zenazn/goji/web"
+	"github.com/zenazn/goji/web/mutil"
+)
+
+// envLoggerKey is the key under which StructuredLogger stashes a
+// request-scoped logger in c.Env, so that downstream handlers can attach
+// additional fields without having to thread a logger through manually.
+const envLoggerKey = "middleware.logger"
+
+// LogSink receives structured logging events from StructuredLogger. A LogSink
+// implementation decides how (and where) those events are rendered: as JSON
+// lines, as calls into a third-party logging library, or as colored console
+// output for humans. Implementations must be safe for concurrent use.
+type LogSink interface {
+	// RequestStart is called once a request has been received but before
+	// it's handed to the wrapped handler.
+	RequestStart(reqID, method, path, remoteAddr, forwardedFor string)
+	// RequestEnd is called after the wrapped handler returns, with the
+	// final response status, the number of bytes written, and the total
+	// time taken to serve the request.
+	RequestEnd(reqID, method, path string, status int, bytesWritten int64, dt time.Duration)
+	// Panic is called when the wrapped handler panics. The sink should
+	// not itself panic or abort the process; StructuredLogger re-panics
+	// after every sink has had a chance to observe the error.
+	Panic(reqID string, err interface{}, stack []byte)
+}
+
+// RequestLogger returns the request-scoped *slog.Logger that StructuredLogger
+// stashed on c.Env, if any, falling back to slog.Default().
+func RequestLogger(c *web.C) *slog.Logger {
+	if c != nil {
+		if l, ok := c.Env[envLoggerKey].(*slog.Logger); ok {
+			return l
+		}
+	}
+	return slog.Default()
+}
+
+// sinkLogger returns the *slog.Logger backing the first SlogSink among
+// sinks, so that the logger StructuredLogger stashes on c.Env actually
+// writes to wherever the application configured its structured logs to go,
+// rather than always falling back to slog.Default().
+func sinkLogger(sinks []LogSink) *slog.Logger {
+	for _, s := range sinks {
+		if ss, ok := s.(*SlogSink); ok {
+			return ss.logger
+		}
+	}
+	return slog.Default()
+}
+
+// StructuredLogger returns a middleware that emits a RequestStart/RequestEnd
+// pair of events to every given sink for each request, and a Panic event if
+// the wrapped handler panics. It is intended to replace Logger in
+// applications that have outgrown console-only logging: pass a JSON or
+// slog-backed sink to ship machine-parseable logs, a HumanSink to keep the
+// existing colored console output, or both at once.
+//
+// A *slog.Logger tagged with the request ID is stored on c.Env so that
+// handlers further down the chain can call RequestLogger(c) and add their
+// own fields.
+func StructuredLogger(sinks ...LogSink) func(*web.C, http.Handler) http.Handler {
+	return func(c *web.C, h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqID := GetReqID(*c)
+			forwardedFor := r.Header.Get("X-Forwarded-For")
+
+			for _, s := range sinks {
+				s.RequestStart(reqID, r.Method, r.URL.String(), r.RemoteAddr, forwardedFor)
+			}
+
+			if c.Env == nil {
+				c.Env = map[interface{}]interface{}{}
+			}
+			c.Env[envLoggerKey] = sinkLogger(sinks).With("req_id", reqID)
+
+			lw := mutil.WrapWriter(w)
+			t1 := time.Now()
+
+			defer func() {
+				if err := recover(); err != nil {
+					stack := debug.Stack()
+					for _, s := range sinks {
+						s.Panic(reqID, err, stack)
+					}
+					panic(err)
+				}
+			}()
+
+			h.ServeHTTP(lw, r)
+
+			if lw.Status() == 0 {
+				lw.WriteHeader(http.StatusOK)
+			}
+			dt := time.Since(t1)
+
+			for _, s := range sinks {
+				s.RequestEnd(reqID, r.Method, r.URL.String(), lw.Status(), int64(lw.BytesWritten()), dt)
+			}
+		})
+	}
+}
+
+// SlogSink is a LogSink that emits events through the standard library's
+// log/slog package.
+type SlogSink struct {
+	logger *slog.Logger
+}
+
+// NewSlogSink returns a LogSink backed by logger. If logger is nil,
+// slog.Default() is used.
+func NewSlogSink(logger *slog.Logger) *SlogSink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &SlogSink{logger: logger}
+}
+
+func (s *SlogSink) RequestStart(reqID, method, path, remoteAddr, forwardedFor string) {
+	s.logger.Info("request started",
+		"req_id", reqID,
+		"method", method,
+		"path", path,
+		"remote_addr", remoteAddr,
+		"forwarded_for", forwardedFor,
+	)
+}
+
+func (s *SlogSink) RequestEnd(reqID, method, path string, status int, bytesWritten int64, dt time.Duration) {
+	s.logger.Info("request finished",
+		"req_id", reqID,
+		"method", method,
+		"path", path,
+		"status", status,
+		"bytes", bytesWritten,
+		"latency", dt,
+	)
+}
+
+func (s *SlogSink) Panic(reqID string, err interface{}, stack []byte) {
+	s.logger.Error("panic serving request",
+		"req_id", reqID,
+		"error", err,
+		"stack", string(stack),
+	)
+}
+
+// HumanSink is a LogSink that writes colored console output in Logger's
+// style: the same "Started ..." line at request entry, and a
+// "Returning <status> in <latency>" line on completion (not the
+// PrettyFormatter line Logger itself prints), so that StructuredLogger keeps
+// a readable "human" log stream.
+type HumanSink struct {
+	out io.Writer
+}
+
+// NewHumanSink returns a LogSink that writes Logger-style colored output to
+// out.
+func NewHumanSink(out io.Writer) *HumanSink {
+	return &HumanSink{out: out}
+}
+
+func (h *HumanSink) RequestStart(reqID, method, path, remoteAddr, forwardedFor string) {
+	remote := forwardedFor
+	if remote == "" {
+		remote = remoteAddr
+	}
+	printStartFields(h.out, reqID, method, path, remote)
+}
+
+func (h *HumanSink) RequestEnd(reqID, method, path string, status int, bytesWritten int64, dt time.Duration) {
+	printEndFields(h.out, reqID, status, dt)
+}
+
+func (h *HumanSink) Panic(reqID string, err interface{}, stack []byte) {
+	printPanicFields(h.out, reqID, err, stack)
+}
+
+func printStartFields(out io.Writer, reqID, method, path, remote string) {
+	var buf bytes.Buffer
+
+	if reqID != "" {
+		cW(&buf, bBlack, "[%s] ", reqID)
+	}
+	buf.WriteString("Started ")
+	cW(&buf, bMagenta, "%s ", method)
+	cW(&buf, nBlue, "%q ", path)
+	buf.WriteString("from ")
+	buf.WriteString(remote)
+	buf.WriteByte('\n')
+
+	out.Write(buf.Bytes())
+}
+
+func printEndFields(out io.Writer, reqID string, status int, dt time.Duration) {
+	var buf bytes.Buffer
+
+	if reqID != "" {
+		cW(&buf, bBlack, "[%s] ", reqID)
+	}
+	buf.WriteString("Returning ")
+	switch {
+	case status < 200:
+		cW(&buf, bBlue, "%03d", status)
+	case status < 300:
+		cW(&buf, bGreen, "%03d", status)
+	case status < 400:
+		cW(&buf, bCyan, "%03d", status)
+	case status < 500:
+		cW(&buf, bYellow, "%03d", status)
+	default:
+		cW(&buf, bRed, "%03d", status)
+	}
+	buf.WriteString(" in ")
+	switch {
+	case dt < 500*time.Millisecond:
+		cW(&buf, nGreen, "%s", dt)
+	case dt < 5*time.Second:
+		cW(&buf, nYellow, "%s", dt)
+	default:
+		cW(&buf, nRed, "%s", dt)
+	}
+	buf.WriteByte('\n')
+
+	out.Write(buf.Bytes())
+}
+
+func printPanicFields(out io.Writer, reqID string, err interface{}, stack []byte) {
+	var buf bytes.Buffer
+
+	if reqID != "" {
+		cW(&buf, bBlack, "[%s] ", reqID)
+	}
+	cW(&buf, bRed, "PANIC: %v\n", err)
+	buf.WriteString(fmt.Sprintf("%s\n", stack))
+
+	out.Write(buf.Bytes())
+}
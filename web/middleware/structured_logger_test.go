@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/zenazn/goji/web"
+)
+
+type fakeSink struct {
+	starts int
+	ends   int
+	panics int
+
+	lastStatus int
+	lastBytes  int64
+	lastErr    interface{}
+}
+
+func (f *fakeSink) RequestStart(reqID, method, path, remoteAddr, forwardedFor string) {
+	f.starts++
+}
+
+func (f *fakeSink) RequestEnd(reqID, method, path string, status int, bytesWritten int64, dt time.Duration) {
+	f.ends++
+	f.lastStatus = status
+	f.lastBytes = bytesWritten
+}
+
+func (f *fakeSink) Panic(reqID string, err interface{}, stack []byte) {
+	f.panics++
+	f.lastErr = err
+}
+
+func TestStructuredLoggerFansOutToEverySink(t *testing.T) {
+	a, b := &fakeSink{}, &fakeSink{}
+
+	m := web.New()
+	m.Use(StructuredLogger(a, b))
+	m.Get("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hi"))
+	})
+
+	r, _ := http.NewRequest("GET", "/ok", nil)
+	m.ServeHTTP(httptest.NewRecorder(), r)
+
+	for name, s := range map[string]*fakeSink{"a": a, "b": b} {
+		if s.starts != 1 || s.ends != 1 || s.panics != 0 {
+			t.Errorf("sink %s: starts=%d ends=%d panics=%d, want 1/1/0", name, s.starts, s.ends, s.panics)
+		}
+		if s.lastStatus != http.StatusCreated {
+			t.Errorf("sink %s: lastStatus = %d, want %d", name, s.lastStatus, http.StatusCreated)
+		}
+		if s.lastBytes != 2 {
+			t.Errorf("sink %s: lastBytes = %d, want 2", name, s.lastBytes)
+		}
+	}
+}
+
+func TestStructuredLoggerRepanicsAfterNotifyingSinks(t *testing.T) {
+	s := &fakeSink{}
+
+	m := web.New()
+	m.Use(StructuredLogger(s))
+	m.Get("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	defer func() {
+		err := recover()
+		if err != "boom" {
+			t.Errorf("recovered err = %v, want %q", err, "boom")
+		}
+		if s.panics != 1 {
+			t.Errorf("sink.panics = %d, want 1", s.panics)
+		}
+		if s.lastErr != "boom" {
+			t.Errorf("sink saw err = %v, want %q", s.lastErr, "boom")
+		}
+		if s.ends != 0 {
+			t.Errorf("sink.ends = %d, want 0 (RequestEnd must not fire on panic)", s.ends)
+		}
+	}()
+
+	r, _ := http.NewRequest("GET", "/panic", nil)
+	m.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+// countingHandler is a slog.Handler that just remembers whether it was
+// invoked, so tests can tell which underlying logger a record went through.
+type countingHandler struct {
+	slog.Handler
+	handled *bool
+}
+
+func (h *countingHandler) Handle(ctx context.Context, r slog.Record) error {
+	*h.handled = true
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs and WithGroup keep derived loggers (such as the one built with
+// With("req_id", ...)) routed through countingHandler.
+func (h *countingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &countingHandler{Handler: h.Handler.WithAttrs(attrs), handled: h.handled}
+}
+
+func (h *countingHandler) WithGroup(name string) slog.Handler {
+	return &countingHandler{Handler: h.Handler.WithGroup(name), handled: h.handled}
+}
+
+func TestStructuredLoggerStashesSinkLogger(t *testing.T) {
+	var handled bool
+	logger := slog.New(&countingHandler{
+		Handler: slog.NewTextHandler(io.Discard, nil),
+		handled: &handled,
+	})
+
+	var c web.C
+	c.Env = nil
+
+	h := StructuredLogger(NewSlogSink(logger))(&c, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The sink's own RequestStart event already went through logger;
+		// only count the downstream line.
+		handled = false
+		RequestLogger(&c).Info("downstream log line")
+	}))
+
+	r, _ := http.NewRequest("GET", "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !handled {
+		t.Error("RequestLogger(c) did not route through the configured SlogSink's logger")
+	}
+}
+
+func TestRequestLoggerFallsBackToDefault(t *testing.T) {
+	if got := RequestLogger(nil); got != slog.Default() {
+		t.Errorf("RequestLogger(nil) = %v, want slog.Default()", got)
+	}
+
+	var c web.C
+	if got := RequestLogger(&c); got != slog.Default() {
+		t.Errorf("RequestLogger(c) with no stashed logger = %v, want slog.Default()", got)
+	}
+}
@@ -0,0 +1,55 @@
+//go:build zap
+
+package middleware
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ZapSink is a LogSink that emits events through Uber's zap logger. It is
+// only compiled in when building with the "zap" build tag, so that the base
+// goji module doesn't force a zap dependency on applications that don't want
+// one.
+type ZapSink struct {
+	logger *zap.Logger
+}
+
+// NewZapSink returns a LogSink backed by logger. If logger is nil,
+// zap.NewNop() is used.
+func NewZapSink(logger *zap.Logger) *ZapSink {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ZapSink{logger: logger}
+}
+
+func (z *ZapSink) RequestStart(reqID, method, path, remoteAddr, forwardedFor string) {
+	z.logger.Info("request started",
+		zap.String("req_id", reqID),
+		zap.String("method", method),
+		zap.String("path", path),
+		zap.String("remote_addr", remoteAddr),
+		zap.String("forwarded_for", forwardedFor),
+	)
+}
+
+func (z *ZapSink) RequestEnd(reqID, method, path string, status int, bytesWritten int64, dt time.Duration) {
+	z.logger.Info("request finished",
+		zap.String("req_id", reqID),
+		zap.String("method", method),
+		zap.String("path", path),
+		zap.Int("status", status),
+		zap.Int64("bytes", bytesWritten),
+		zap.Duration("latency", dt),
+	)
+}
+
+func (z *ZapSink) Panic(reqID string, err interface{}, stack []byte) {
+	z.logger.Error("panic serving request",
+		zap.String("req_id", reqID),
+		zap.Any("error", err),
+		zap.ByteString("stack", stack),
+	)
+}
@@ -0,0 +1,51 @@
+//go:build zerolog
+
+package middleware
+
+import (
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+// ZerologSink is a LogSink that emits events through rs/zerolog. It is only
+// compiled in when building with the "zerolog" build tag, so that the base
+// goji module doesn't force a zerolog dependency on applications that don't
+// want one.
+type ZerologSink struct {
+	logger zerolog.Logger
+}
+
+// NewZerologSink returns a LogSink backed by logger.
+func NewZerologSink(logger zerolog.Logger) *ZerologSink {
+	return &ZerologSink{logger: logger}
+}
+
+func (z *ZerologSink) RequestStart(reqID, method, path, remoteAddr, forwardedFor string) {
+	z.logger.Info().
+		Str("req_id", reqID).
+		Str("method", method).
+		Str("path", path).
+		Str("remote_addr", remoteAddr).
+		Str("forwarded_for", forwardedFor).
+		Msg("request started")
+}
+
+func (z *ZerologSink) RequestEnd(reqID, method, path string, status int, bytesWritten int64, dt time.Duration) {
+	z.logger.Info().
+		Str("req_id", reqID).
+		Str("method", method).
+		Str("path", path).
+		Int("status", status).
+		Int64("bytes", bytesWritten).
+		Dur("latency", dt).
+		Msg("request finished")
+}
+
+func (z *ZerologSink) Panic(reqID string, err interface{}, stack []byte) {
+	z.logger.Error().
+		Str("req_id", reqID).
+		Interface("error", err).
+		Bytes("stack", stack).
+		Msg("panic serving request")
+}